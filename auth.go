@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// tokenType distinguishes access tokens from refresh tokens inside a JWT's
+// claims, so a refresh token can't be replayed as an access token.
+type tokenType string
+
+const (
+	tokenTypeAccess  tokenType = "access"
+	tokenTypeRefresh tokenType = "refresh"
+)
+
+// authClaims are the custom JWT claims issued for both access and refresh
+// tokens.
+type authClaims struct {
+	UserID uint      `json:"user_id"`
+	Role   Role      `json:"role"`
+	Type   tokenType `json:"type"`
+	jwt.RegisteredClaims
+}
+
+var errInvalidToken = errors.New("invalid or expired token")
+
+// hashPassword bcrypt-hashes a plaintext password using the configured cost.
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cfg.BcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// checkPassword reports whether password matches the stored bcrypt hash.
+func checkPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// issueToken signs a JWT of the given type for the user, valid for ttl.
+func issueToken(user *User, typ tokenType, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := authClaims{
+		UserID: user.ID,
+		Role:   user.Role,
+		Type:   typ,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(cfg.JWTSecret)
+}
+
+// issueTokenPair issues a short-lived access token and a longer-lived
+// refresh token for user.
+func issueTokenPair(user *User) (*TokenResponse, error) {
+	access, err := issueToken(user, tokenTypeAccess, cfg.AccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := issueToken(user, tokenTypeRefresh, cfg.RefreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenResponse{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    int(cfg.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// parseToken validates token and checks it is of the expected type.
+func parseToken(tokenString string, expected tokenType) (*authClaims, error) {
+	claims := &authClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errInvalidToken
+		}
+		return cfg.JWTSecret, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errInvalidToken
+	}
+	if claims.Type != expected {
+		return nil, errInvalidToken
+	}
+	return claims, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// monthPattern validates the YYYY-MM month format used by budgets and the
+// cashflow report.
+var monthPattern = regexp.MustCompile(`^\d{4}-\d{2}$`)
+
+// createBudget handles POST /budgets
+func createBudget(w http.ResponseWriter, r *http.Request) {
+	var req BudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if !monthPattern.MatchString(req.Month) || req.CategoryID == 0 {
+		respondWithError(w, http.StatusBadRequest, "A YYYY-MM month and category ID are required")
+		return
+	}
+
+	if err := db.First(&Category{}, req.CategoryID).Error; err != nil {
+		respondWithError(w, http.StatusBadRequest, "Category not found")
+		return
+	}
+
+	budget := Budget{
+		Month:           req.Month,
+		CategoryID:      req.CategoryID,
+		AllocatedAmount: req.AllocatedAmount,
+	}
+	if err := db.Create(&budget).Error; err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create budget: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, Response{
+		Success: true,
+		Message: "Budget created successfully",
+		Data:    budget,
+	})
+}
+
+// listBudgets handles GET /budgets?month=YYYY-MM
+func listBudgets(w http.ResponseWriter, r *http.Request) {
+	month := r.URL.Query().Get("month")
+	if !monthPattern.MatchString(month) {
+		respondWithError(w, http.StatusBadRequest, "A YYYY-MM month query parameter is required")
+		return
+	}
+
+	var budgets []Budget
+	if err := db.Where("month = ?", month).Find(&budgets).Error; err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error listing budgets: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: fmt.Sprintf("Found %d budget(s) for %s", len(budgets), month),
+		Data:    budgets,
+	})
+}
+
+// getCashflowReport handles GET /reports/cashflow?from=&to=&group_by=category,
+// aggregating splits into per-category credit/debit/net totals with a single
+// grouped query. Non-admin callers only see splits against accounts they
+// own; admins see every account.
+func getCashflowReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, err := time.Parse("2006-01-02", query.Get("from"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid or missing \"from\" date, expected YYYY-MM-DD")
+		return
+	}
+	to, err := time.Parse("2006-01-02", query.Get("to"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid or missing \"to\" date, expected YYYY-MM-DD")
+		return
+	}
+	// time.Parse gives midnight of the "to" date; advance to midnight of the
+	// following day so the comparison below includes all of the "to" date
+	// instead of excluding everything after 00:00:00 on it.
+	toExclusive := to.AddDate(0, 0, 1)
+
+	if groupBy := query.Get("group_by"); groupBy != "" && groupBy != "category" {
+		respondWithError(w, http.StatusBadRequest, "Only group_by=category is supported")
+		return
+	}
+
+	dbQuery := db.Table("splits").
+		Select("COALESCE(categories.name, 'Uncategorized') AS category, "+
+			"COALESCE(SUM(CASE WHEN splits.amount > 0 THEN splits.amount ELSE 0 END), 0) AS credits, "+
+			"COALESCE(SUM(CASE WHEN splits.amount < 0 THEN -splits.amount ELSE 0 END), 0) AS debits").
+		Joins("JOIN journal_entries ON journal_entries.id = splits.journal_entry_id").
+		Joins("JOIN bank_accounts ON bank_accounts.id = splits.account_id").
+		Joins("LEFT JOIN categories ON categories.id = splits.category_id").
+		Where("journal_entries.entry_time >= ? AND journal_entries.entry_time < ?", from, toExclusive)
+
+	if currentRole(r) != RoleAdmin {
+		dbQuery = dbQuery.Where("bank_accounts.owner_id = ?", currentUserID(r))
+	}
+
+	var rows []CashflowReportEntry
+	err = dbQuery.
+		Group("COALESCE(categories.name, 'Uncategorized')").
+		Scan(&rows).Error
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error building cashflow report: "+err.Error())
+		return
+	}
+
+	report := make(map[string]CashflowReportEntry, len(rows))
+	for _, row := range rows {
+		row.Net = row.Credits - row.Debits
+		report[row.Category] = row
+	}
+
+	respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: fmt.Sprintf("Found %d categor(ies)", len(rows)),
+		Data:    report,
+	})
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// createCategory handles POST /categories
+func createCategory(w http.ResponseWriter, r *http.Request) {
+	var req CategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Name == "" || req.Type == "" {
+		respondWithError(w, http.StatusBadRequest, "Name and type are required")
+		return
+	}
+
+	if req.ParentID != nil {
+		if err := db.First(&Category{}, *req.ParentID).Error; err != nil {
+			respondWithError(w, http.StatusBadRequest, "Parent category not found")
+			return
+		}
+	}
+
+	category := Category{
+		Name:     req.Name,
+		ParentID: req.ParentID,
+		Type:     req.Type,
+	}
+	if err := db.Create(&category).Error; err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create category: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, Response{
+		Success: true,
+		Message: "Category created successfully",
+		Data:    category,
+	})
+}
+
+// listCategories handles GET /categories
+func listCategories(w http.ResponseWriter, r *http.Request) {
+	var categories []Category
+	if err := db.Find(&categories).Error; err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error listing categories: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: fmt.Sprintf("Found %d category(ies)", len(categories)),
+		Data:    categories,
+	})
+}
+
+// updateCategory handles PATCH /categories/{id}
+func updateCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := parseAccountID(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	var category Category
+	if err := db.First(&category, id).Error; err != nil {
+		respondWithError(w, http.StatusNotFound, "Category not found")
+		return
+	}
+
+	var req CategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Name != "" {
+		category.Name = req.Name
+	}
+	if req.Type != "" {
+		category.Type = req.Type
+	}
+	if req.ParentID != nil {
+		category.ParentID = req.ParentID
+	}
+
+	if err := db.Save(&category).Error; err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update category: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Category updated successfully",
+		Data:    category,
+	})
+}
+
+// deleteCategory handles DELETE /categories/{id}
+func deleteCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := parseAccountID(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	if err := db.First(&Category{}, id).Error; err != nil {
+		respondWithError(w, http.StatusNotFound, "Category not found")
+		return
+	}
+
+	if err := db.Delete(&Category{}, id).Error; err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete category: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Category deleted successfully",
+	})
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config holds runtime configuration sourced from environment variables.
+// Unset variables, the secrets included, fall back to the development
+// defaults written in loadConfig.
+type Config struct {
+	JWTSecret       []byte
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	BcryptCost      int
+
+	DBDriver   string
+	DBHost     string
+	DBPort     string
+	DBUser     string
+	DBPassword string
+	DBName     string
+	// SQLitePath is the database file used when DBDriver is "sqlite".
+	SQLitePath string
+}
+
+var cfg Config
+
+// loadConfig populates cfg from the environment, falling back to
+// development-friendly defaults when a variable is unset.
+func loadConfig() {
+	cfg = Config{
+		JWTSecret:       []byte(getEnv("JWT_SECRET", "dev-insecure-secret-change-me")),
+		AccessTokenTTL:  getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getEnvDuration("REFRESH_TOKEN_TTL", 24*time.Hour),
+		BcryptCost:      getEnvInt("BCRYPT_COST", 12),
+
+		DBDriver:   getEnv("DB_DRIVER", "mysql"),
+		DBHost:     getEnv("DB_HOST", "localhost"),
+		DBPort:     getEnv("DB_PORT", "3306"),
+		DBUser:     getEnv("DB_USER", "root"),
+		DBPassword: getEnv("DB_PASSWORD", "12345678"),
+		DBName:     getEnv("DB_NAME", "bank_app"),
+		SQLitePath: getEnv("DB_PATH", "bank_app.db"),
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	if v := os.Getenv(key); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
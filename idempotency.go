@@ -0,0 +1,137 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// errIdempotencyConflict signals that an Idempotency-Key was reused with a
+// different request body.
+var errIdempotencyConflict = errors.New("idempotency key reused with a different request")
+
+// errIdempotencyInProgress signals that another request is still running fn
+// for this Idempotency-Key; the record has been claimed but not yet filled
+// in with a response.
+var errIdempotencyInProgress = errors.New("idempotency key is already being processed by another request")
+
+// hashRequestBody fingerprints a raw request body for idempotency comparison.
+func hashRequestBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// findIdempotencyRecord looks up a previously stored response for
+// (accountID, key). It returns errIdempotencyConflict if a record exists
+// but was stored for a different request body.
+func findIdempotencyRecord(accountID uint, key string, requestHash string) (*IdempotencyRecord, error) {
+	var record IdempotencyRecord
+	err := db.Where("account_id = ? AND key = ?", accountID, key).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if record.RequestHash != requestHash {
+		return nil, errIdempotencyConflict
+	}
+	return &record, nil
+}
+
+// claimIdempotencyKey atomically claims (accountID, key) by inserting a
+// placeholder record for it before fn runs, using the table's
+// (account_id, key) unique index as the arbiter: of several concurrent
+// requests carrying the same Idempotency-Key, only one INSERT can succeed.
+// The rest fail immediately with a unique-constraint error and fall back to
+// reading whatever the winner left behind, without ever having run fn(). This
+// replaces the old check-then-insert sequence (SELECT, then INSERT only
+// after fn() completed), which let every concurrent request see "no record
+// yet" and all run fn().
+func claimIdempotencyKey(accountID uint, key, requestHash string) (record *IdempotencyRecord, claimed bool, err error) {
+	claim := IdempotencyRecord{
+		AccountID:   accountID,
+		Key:         key,
+		RequestHash: requestHash,
+	}
+	if err := db.Create(&claim).Error; err == nil {
+		return &claim, true, nil
+	}
+
+	existing, err := findIdempotencyRecord(accountID, key, requestHash)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing == nil {
+		return nil, false, fmt.Errorf("idempotency key claim lost its race but left no record behind")
+	}
+	if existing.ResponseCode == 0 {
+		return nil, false, errIdempotencyInProgress
+	}
+	return existing, false, nil
+}
+
+// withIdempotency runs fn at most once per (accountID, Idempotency-Key)
+// pair, replaying the stored response on retries with the same body and
+// returning a 409 if the key is reused with a different body, or if another
+// request with the same key is still in flight. Requests without an
+// Idempotency-Key header always run fn directly. The (accountID, key) row is
+// claimed with an upfront insert before fn runs, not after, so two
+// concurrent requests carrying the same key can't both execute fn.
+func withIdempotency(w http.ResponseWriter, r *http.Request, accountID uint, rawBody []byte, fn func() (int, interface{})) {
+	key := r.Header.Get(idempotencyKeyHeader)
+	if key == "" {
+		code, payload := fn()
+		respondWithJSON(w, code, payload)
+		return
+	}
+
+	requestHash := hashRequestBody(rawBody)
+
+	record, claimed, err := claimIdempotencyKey(accountID, key, requestHash)
+	if err != nil {
+		switch {
+		case errors.Is(err, errIdempotencyConflict):
+			respondWithError(w, http.StatusConflict, "Idempotency-Key was already used with a different request")
+		case errors.Is(err, errIdempotencyInProgress):
+			respondWithError(w, http.StatusConflict, "A request with this Idempotency-Key is already in progress")
+		default:
+			respondWithError(w, http.StatusInternalServerError, "Error checking idempotency key: "+err.Error())
+		}
+		return
+	}
+	if !claimed {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(record.ResponseCode)
+		w.Write([]byte(record.ResponseBody))
+		return
+	}
+
+	code, payload := fn()
+
+	responseBody, err := json.Marshal(payload)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error encoding response: "+err.Error())
+		return
+	}
+
+	err = db.Model(&IdempotencyRecord{}).Where("id = ?", record.ID).Updates(map[string]interface{}{
+		"response_code": code,
+		"response_body": string(responseBody),
+	}).Error
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error storing idempotency record: "+err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(responseBody)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// TestConcurrentWithdrawalsNeverOverdraw fires 50 concurrent withdrawal
+// requests of 1 unit each against a 30-unit account and asserts that exactly
+// 30 succeed and the final balance ends at zero, never negative, exercising
+// the locked balance re-check in createDepositOrWithdrawal under real
+// concurrency (on SQLite the lock is the database's single-writer lock).
+func TestConcurrentWithdrawalsNeverOverdraw(t *testing.T) {
+	setupTestDB(t, driverSQLite)
+	router := newRouter()
+
+	code, accessToken := registerAndLogin(t, router, "concurrent-withdrawals@example.com")
+	if code != http.StatusOK {
+		t.Fatalf("login: got status %d", code)
+	}
+
+	const startingBalance = 30.0
+	code, acctResp := doJSON(t, router, http.MethodPost, "/accounts", accessToken, CreateAccountRequest{
+		AccountHolderName: "Concurrent Withdrawals",
+		AccountNumber:     "ACC-CONCURRENT-WITHDRAW",
+		AccountType:       AccountTypeBank,
+		StartingBalance:   startingBalance,
+	})
+	if code != http.StatusCreated {
+		t.Fatalf("create account: got status %d", code)
+	}
+	account := acctResp.Data.(map[string]interface{})
+	accountID := uint(account["id"].(float64))
+
+	const attempts = 50
+	var wg sync.WaitGroup
+	successes := make([]bool, attempts)
+	wg.Add(attempts)
+	for i := 0; i < attempts; i++ {
+		i := i
+		go func() {
+			defer wg.Done()
+			code, _ := doJSON(t, router, http.MethodPost, "/transactions/withdraw", accessToken, TransactionRequest{
+				AccountID: accountID,
+				Amount:    1,
+			})
+			successes[i] = code == http.StatusCreated
+		}()
+	}
+	wg.Wait()
+
+	var succeeded int
+	for _, ok := range successes {
+		if ok {
+			succeeded++
+		}
+	}
+	if succeeded != int(startingBalance) {
+		t.Fatalf("expected exactly %d of %d withdrawals to succeed, got %d", int(startingBalance), attempts, succeeded)
+	}
+
+	balance, err := getAccountBalanceById(accountID)
+	if err != nil {
+		t.Fatalf("getAccountBalanceById: %v", err)
+	}
+	if balance < 0 {
+		t.Fatalf("final balance went negative: %v", balance)
+	}
+	if balance != 0 {
+		t.Fatalf("final balance: got %v, want 0", balance)
+	}
+}
+
+// registerAndLogin registers a new user with email and returns the login
+// HTTP status and access token.
+func registerAndLogin(t *testing.T, router http.Handler, email string) (int, string) {
+	t.Helper()
+
+	code, _ := doJSON(t, router, http.MethodPost, "/users", "", RegisterUserRequest{
+		Email:    email,
+		Password: "correct-horse",
+	})
+	if code != http.StatusCreated {
+		t.Fatalf("register %s: got status %d", email, code)
+	}
+
+	code, loginResp := doJSON(t, router, http.MethodPost, "/users/login", "", LoginRequest{
+		Email:    email,
+		Password: "correct-horse",
+	})
+	if code != http.StatusOK {
+		return code, ""
+	}
+	tokens, ok := loginResp.Data.(map[string]interface{})
+	if !ok {
+		t.Fatalf("login response for %s missing token data: %+v", email, loginResp.Data)
+	}
+	accessToken, _ := tokens["access_token"].(string)
+	if accessToken == "" {
+		t.Fatalf("login response for %s missing access_token", email)
+	}
+	return code, accessToken
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// systemCashAccountNumber identifies the well-known Cash account used as the
+// counterparty for plain deposits and withdrawals, so that every movement of
+// money is still represented as a balanced JournalEntry.
+const systemCashAccountNumber = "SYSTEM-CASH"
+
+// splitTolerance accounts for floating point rounding when checking that a
+// journal entry's splits sum to zero.
+const splitTolerance = 0.005
+
+// systemOpeningBalanceAccountNumber identifies the Equity account used as
+// the counterparty when seeding a new account with a starting balance.
+const systemOpeningBalanceAccountNumber = "SYSTEM-OPENING-BALANCE"
+
+// ensureSystemAccounts creates the system accounts used as counterparties for
+// deposit/withdraw if they do not already exist.
+func ensureSystemAccounts() error {
+	_, err := getOrCreateSystemAccount(systemCashAccountNumber, "System Cash", AccountTypeCash)
+	return err
+}
+
+// getOrCreateSystemAccount fetches the system account identified by
+// accountNumber, creating it with the given name/type on first use.
+func getOrCreateSystemAccount(accountNumber, name string, accountType AccountType) (*BankAccount, error) {
+	var account BankAccount
+	err := db.Where("account_number = ?", accountNumber).First(&account).Error
+	if err == nil {
+		return &account, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	account = BankAccount{
+		AccountHolderName: name,
+		AccountNumber:     accountNumber,
+		AccountType:       accountType,
+	}
+	if err := db.Create(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// getSystemCashAccount fetches the system Cash counterparty account.
+func getSystemCashAccount() (*BankAccount, error) {
+	var account BankAccount
+	if err := db.Where("account_number = ?", systemCashAccountNumber).First(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// createJournalEntryInTx persists a balanced JournalEntry and its Splits
+// using tx, rejecting entries whose splits don't sum to zero. Callers that
+// need the insert to participate in a larger atomic operation (e.g. a
+// balance check) should use this directly inside their own db.Transaction;
+// createJournalEntry is a convenience wrapper for the common case.
+func createJournalEntryInTx(tx *gorm.DB, memo string, splits []Split) (*JournalEntry, error) {
+	var sum float64
+	for _, s := range splits {
+		sum += s.Amount
+	}
+	if sum < -splitTolerance || sum > splitTolerance {
+		return nil, fmt.Errorf("splits must sum to zero, got %.2f", sum)
+	}
+
+	entry := JournalEntry{
+		Memo:      memo,
+		EntryTime: time.Now(),
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return nil, err
+	}
+	for i := range splits {
+		splits[i].JournalEntryID = entry.ID
+		if err := tx.Create(&splits[i]).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	entry.Splits = splits
+	return &entry, nil
+}
+
+// createJournalEntry persists a balanced JournalEntry and its Splits inside a
+// single new DB transaction.
+func createJournalEntry(memo string, splits []Split) (*JournalEntry, error) {
+	var entry *JournalEntry
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		entry, err = createJournalEntryInTx(tx, memo, splits)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// lockAccountForUpdate fetches an account row with a row lock (SELECT ...
+// FOR UPDATE) so concurrent debits against it serialize instead of racing
+// on a stale balance read. SQLite has no such clause, so the clause is
+// skipped; concurrent transactions instead serialize on SQLite's own
+// single-writer file lock, which only actually blocks (rather than failing
+// with SQLITE_BUSY) because the sqlite dialector sets a busy_timeout and
+// limitSQLiteConnections caps the pool to one connection (see storage.go).
+func lockAccountForUpdate(tx *gorm.DB, accountID uint) (*BankAccount, error) {
+	query := tx.Model(&BankAccount{})
+	if cfg.DBDriver != driverSQLite {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var account BankAccount
+	if err := query.First(&account, accountID).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// accountBalance sums the account's splits using q (either db or an
+// in-flight transaction) and applies the sign convention implied by its
+// AccountType (credit-normal types are negated).
+func accountBalance(q *gorm.DB, account *BankAccount) (float64, error) {
+	var rawSum float64
+	if err := q.Model(&Split{}).
+		Where("account_id = ?", account.ID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&rawSum).Error; err != nil {
+		return 0, err
+	}
+
+	if account.AccountType.IsCreditNormal() {
+		return -rawSum, nil
+	}
+	return rawSum, nil
+}
+
+// balanceBefore sums the account's splits created strictly before cutoff and
+// applies the sign convention implied by its AccountType, giving the
+// account's running balance as of (but excluding) cutoff.
+func balanceBefore(q *gorm.DB, account *BankAccount, cutoff time.Time) (float64, error) {
+	var rawSum float64
+	if err := q.Model(&Split{}).
+		Where("account_id = ? AND created_at < ?", account.ID, cutoff).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&rawSum).Error; err != nil {
+		return 0, err
+	}
+
+	if account.AccountType.IsCreditNormal() {
+		return -rawSum, nil
+	}
+	return rawSum, nil
+}
+
+// getAccountBalanceById sums the account's splits and applies the sign
+// convention implied by its AccountType.
+func getAccountBalanceById(accountID uint) (float64, error) {
+	var account BankAccount
+	if err := db.First(&account, accountID).Error; err != nil {
+		return 0, err
+	}
+	return accountBalance(db, &account)
+}
+
+// createDepositOrWithdrawal handles deposit/withdraw requests by writing a
+// balanced JournalEntry against the system Cash account. Withdrawals lock
+// the account row and re-check the balance inside the same transaction that
+// inserts the entry, so two concurrent withdrawals can't both pass the
+// balance check against a balance neither of them has actually spent yet.
+func createDepositOrWithdrawal(w http.ResponseWriter, r *http.Request, isWithdrawal bool) {
+	rawBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+	defer r.Body.Close()
+
+	var req TransactionRequest
+	if err := json.Unmarshal(rawBody, &req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if req.AccountID == 0 || req.Amount <= 0 {
+		respondWithError(w, http.StatusBadRequest, "Valid account ID and positive amount are required")
+		return
+	}
+
+	var account BankAccount
+	if err := db.First(&account, req.AccountID).Error; err != nil {
+		respondWithError(w, http.StatusNotFound, "Account not found")
+		return
+	}
+
+	if !isOwnerOrAdmin(r, account.OwnerID) {
+		respondWithError(w, http.StatusForbidden, "You do not have access to this account")
+		return
+	}
+
+	if req.CategoryID != nil {
+		if err := db.First(&Category{}, *req.CategoryID).Error; err != nil {
+			respondWithError(w, http.StatusBadRequest, "Category not found")
+			return
+		}
+	}
+
+	cash, err := getSystemCashAccount()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "System cash account unavailable: "+err.Error())
+		return
+	}
+
+	memo := "Deposit"
+	if isWithdrawal {
+		memo = "Withdrawal"
+	}
+
+	withIdempotency(w, r, req.AccountID, rawBody, func() (int, interface{}) {
+		var entry *JournalEntry
+
+		if isWithdrawal {
+			err = db.Transaction(func(tx *gorm.DB) error {
+				locked, err := lockAccountForUpdate(tx, req.AccountID)
+				if err != nil {
+					return err
+				}
+				balance, err := accountBalance(tx, locked)
+				if err != nil {
+					return err
+				}
+				if balance < req.Amount {
+					return fmt.Errorf("insufficient funds")
+				}
+
+				entry, err = createJournalEntryInTx(tx, memo, []Split{
+					{AccountID: account.ID, Amount: -req.Amount, CategoryID: req.CategoryID},
+					{AccountID: cash.ID, Amount: req.Amount},
+				})
+				return err
+			})
+		} else {
+			entry, err = createJournalEntry(memo, []Split{
+				{AccountID: account.ID, Amount: req.Amount, CategoryID: req.CategoryID},
+				{AccountID: cash.ID, Amount: -req.Amount},
+			})
+		}
+
+		if err != nil {
+			if isWithdrawal && err.Error() == "insufficient funds" {
+				return http.StatusBadRequest, Response{Success: false, Message: "Insufficient funds"}
+			}
+			return http.StatusInternalServerError, Response{Success: false, Message: "Failed to create transaction: " + err.Error()}
+		}
+
+		message := "Deposit completed successfully"
+		if isWithdrawal {
+			message = "Withdrawal completed successfully"
+		}
+		return http.StatusCreated, Response{Success: true, Message: message, Data: entry}
+	})
+}
+
+// createDeposit handles deposit transactions
+func createDeposit(w http.ResponseWriter, r *http.Request) {
+	createDepositOrWithdrawal(w, r, false)
+}
+
+// createWithdrawal handles withdrawal transactions
+func createWithdrawal(w http.ResponseWriter, r *http.Request) {
+	createDepositOrWithdrawal(w, r, true)
+}
+
+// createTransfer handles POST /transfers, writing a single balanced journal
+// entry moving money directly between two accounts. Like withdrawals, the
+// source account is locked and its balance re-checked inside the same
+// transaction that inserts the entry.
+func createTransfer(w http.ResponseWriter, r *http.Request) {
+	rawBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+	defer r.Body.Close()
+
+	var req TransferRequest
+	if err := json.Unmarshal(rawBody, &req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if req.FromAccountID == 0 || req.ToAccountID == 0 || req.Amount <= 0 {
+		respondWithError(w, http.StatusBadRequest, "Valid from/to account IDs and a positive amount are required")
+		return
+	}
+	if req.FromAccountID == req.ToAccountID {
+		respondWithError(w, http.StatusBadRequest, "from_account_id and to_account_id must differ")
+		return
+	}
+
+	var fromAccount BankAccount
+	if err := db.First(&fromAccount, req.FromAccountID).Error; err != nil {
+		respondWithError(w, http.StatusNotFound, "Source account not found")
+		return
+	}
+	if err := db.First(&BankAccount{}, req.ToAccountID).Error; err != nil {
+		respondWithError(w, http.StatusNotFound, "Destination account not found")
+		return
+	}
+
+	if !isOwnerOrAdmin(r, fromAccount.OwnerID) {
+		respondWithError(w, http.StatusForbidden, "You do not have access to this account")
+		return
+	}
+
+	if req.CategoryID != nil {
+		if err := db.First(&Category{}, *req.CategoryID).Error; err != nil {
+			respondWithError(w, http.StatusBadRequest, "Category not found")
+			return
+		}
+	}
+
+	withIdempotency(w, r, req.FromAccountID, rawBody, func() (int, interface{}) {
+		var entry *JournalEntry
+		err := db.Transaction(func(tx *gorm.DB) error {
+			locked, err := lockAccountForUpdate(tx, req.FromAccountID)
+			if err != nil {
+				return err
+			}
+			balance, err := accountBalance(tx, locked)
+			if err != nil {
+				return err
+			}
+			if balance < req.Amount {
+				return fmt.Errorf("insufficient funds")
+			}
+
+			entry, err = createJournalEntryInTx(tx, req.Memo, []Split{
+				{AccountID: req.FromAccountID, Amount: -req.Amount, Memo: req.Memo, CategoryID: req.CategoryID},
+				{AccountID: req.ToAccountID, Amount: req.Amount, Memo: req.Memo, CategoryID: req.CategoryID},
+			})
+			return err
+		})
+
+		if err != nil {
+			if err.Error() == "insufficient funds" {
+				return http.StatusBadRequest, Response{Success: false, Message: "Insufficient funds"}
+			}
+			return http.StatusInternalServerError, Response{Success: false, Message: "Failed to create transfer: " + err.Error()}
+		}
+
+		return http.StatusCreated, Response{Success: true, Message: "Transfer completed successfully", Data: entry}
+	})
+}
+
+// getAccountLedger handles GET /accounts/{id}/ledger?since=&limit=, returning
+// the account's splits in chronological order with a running balance.
+func getAccountLedger(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := strconv.Atoi(params["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid account ID")
+		return
+	}
+
+	var account BankAccount
+	if err := db.First(&account, id).Error; err != nil {
+		respondWithError(w, http.StatusNotFound, "Account not found")
+		return
+	}
+
+	if !isOwnerOrAdmin(r, account.OwnerID) {
+		respondWithError(w, http.StatusForbidden, "You do not have access to this account")
+		return
+	}
+
+	query := r.URL.Query()
+	limit := 50
+	if l := query.Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	dbQuery := db.Model(&Split{}).Where("account_id = ?", id)
+	var seed float64
+	if since := query.Get("since"); since != "" {
+		sinceTime, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid since timestamp, expected RFC3339")
+			return
+		}
+		dbQuery = dbQuery.Where("created_at >= ?", sinceTime)
+
+		// Seed the running total with the balance as of, but excluding,
+		// since, so a paginated window doesn't report a running balance
+		// that resets to zero at the start of the page.
+		seed, err = balanceBefore(db, &account, sinceTime)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error computing starting balance: "+err.Error())
+			return
+		}
+	}
+
+	var splits []Split
+	if err := dbQuery.Order("created_at ASC, id ASC").Limit(limit).Find(&splits).Error; err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error fetching ledger: "+err.Error())
+		return
+	}
+
+	creditNormal := account.AccountType.IsCreditNormal()
+	running := seed
+	entries := make([]LedgerEntry, 0, len(splits))
+	for _, s := range splits {
+		signed := s.Amount
+		if creditNormal {
+			signed = -signed
+		}
+		running += signed
+		entries = append(entries, LedgerEntry{
+			SplitID:        s.ID,
+			JournalEntryID: s.JournalEntryID,
+			Amount:         signed,
+			Memo:           s.Memo,
+			CreatedAt:      s.CreatedAt,
+			RunningBalance: running,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: fmt.Sprintf("Found %d ledger entries", len(entries)),
+		Data:    entries,
+	})
+}
@@ -1,133 +1,91 @@
 package main
 
 import (
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
-	"time"
 
-	_ "github.com/go-sql-driver/mysql"
 	"github.com/gorilla/mux"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
-// Database connection parameters
-const (
-	dbUser     = "root"
-	dbPassword = "12345678"
-	dbHost     = "localhost"
-	dbPort     = 3306
-	dbName     = "bank_app"
-)
-
-// BankAccount model
-type BankAccount struct {
-	ID                uint          `json:"id" gorm:"primaryKey"`
-	AccountHolderName string        `json:"account_holder_name" gorm:"size:255;not null"`
-	AccountNumber     string        `json:"account_number" gorm:"size:50;not null;uniqueIndex"`
-	Transactions      []Transaction `json:"-" gorm:"foreignKey:AccountID"`
-}
-
-// Transaction model
-type Transaction struct {
-	ID        uint        `json:"id" gorm:"primaryKey"`
-	AccountID uint        `json:"account_id" gorm:"not null"`
-	TransType string      `json:"trans_type" gorm:"type:enum('credit','debit');not null"`
-	Amount    float64     `json:"amount" gorm:"type:decimal(15,2);not null"`
-	TransTime time.Time   `json:"trans_time" gorm:"not null"`
-	Account   BankAccount `json:"-" gorm:"foreignKey:AccountID"`
-}
-
-// CreateAccountRequest structure for account creation
-type CreateAccountRequest struct {
-	AccountHolderName string `json:"account_holder_name"`
-	AccountNumber     string `json:"account_number"`
-}
-
-// TransactionRequest structure for transaction creation
-type TransactionRequest struct {
-	AccountID uint    `json:"account_id"`
-	Amount    float64 `json:"amount"`
-}
-
-// Response structures
-type Response struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-}
-
-// BalanceResponse structure
-type BalanceResponse struct {
-	AccountID     uint    `json:"account_id"`
-	AccountNumber string  `json:"account_number"`
-	AccountHolder string  `json:"account_holder"`
-	Balance       float64 `json:"balance"`
-}
-
 var db *gorm.DB
 
 func main() {
+	loadConfig()
+
 	// First create the database if it doesn't exist
 	if err := createDatabaseIfNotExists(); err != nil {
 		log.Fatalf("Failed to create database: %v", err)
 	}
 
-	// Connect to the database
-	var err error
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local&allowNativePasswords=true",
-		dbUser, dbPassword, dbHost, dbPort, dbName)
+	// Connect to the database using the configured driver
+	dialector, err := openDialector()
+	if err != nil {
+		log.Fatalf("Failed to configure database driver: %v", err)
+	}
 
-	db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err = gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
-	log.Println("Connected to database successfully")
+	if cfg.DBDriver == driverSQLite {
+		if err := limitSQLiteConnections(db); err != nil {
+			log.Fatalf("Failed to configure SQLite connection pool: %v", err)
+		}
+	}
+	log.Printf("Connected to %s database successfully", cfg.DBDriver)
 
 	// Auto migrate the schema
-	err = db.AutoMigrate(&BankAccount{}, &Transaction{})
+	err = db.AutoMigrate(&BankAccount{}, &JournalEntry{}, &Split{}, &User{}, &IdempotencyRecord{}, &Category{}, &Budget{})
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 	log.Println("Database migrated successfully")
 
-	// Router setup
-	r := mux.NewRouter()
-
-	// Define routes
-	r.HandleFunc("/accounts", createAccount).Methods("POST")
-	r.HandleFunc("/accounts/search", searchAccounts).Methods("GET")
-	r.HandleFunc("/transactions/deposit", createDeposit).Methods("POST")
-	r.HandleFunc("/transactions/withdraw", createWithdrawal).Methods("POST")
-	r.HandleFunc("/accounts/{id}/balance", getBalance).Methods("GET")
+	if err := ensureSystemAccounts(); err != nil {
+		log.Fatalf("Failed to ensure system accounts: %v", err)
+	}
 
 	// Start server
 	log.Println("Server started on port 8080")
-	log.Fatal(http.ListenAndServe(":8080", r))
+	log.Fatal(http.ListenAndServe(":8080", newRouter()))
 }
 
-// createDatabaseIfNotExists creates the database if it doesn't exist
-func createDatabaseIfNotExists() error {
-	// Connect to MySQL without specifying a database
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/", dbUser, dbPassword, dbHost, dbPort)
-	sqlDB, err := sql.Open("mysql", dsn)
-	if err != nil {
-		return fmt.Errorf("error connecting to MySQL: %v", err)
-	}
-	defer sqlDB.Close()
-
-	// Create the database if it doesn't exist
-	_, err = sqlDB.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", dbName))
-	if err != nil {
-		return fmt.Errorf("error creating database: %v", err)
-	}
+// newRouter builds the full set of public and protected routes against the
+// package-level db. Split out of main so tests can exercise the handler set
+// directly with httptest, without binding a real listener.
+func newRouter() *mux.Router {
+	r := mux.NewRouter()
 
-	log.Printf("Database '%s' ensured", dbName)
-	return nil
+	// Public routes: no bearer token required
+	r.HandleFunc("/users", registerUser).Methods("POST")
+	r.HandleFunc("/users/login", loginUser).Methods("POST")
+	r.HandleFunc("/tokens/refresh", refreshAccessToken).Methods("POST")
+
+	// Protected routes: require a valid access token
+	protected := r.NewRoute().Subrouter()
+	protected.Use(AuthMiddleware)
+	protected.HandleFunc("/accounts", createAccount).Methods("POST")
+	protected.HandleFunc("/accounts/search", searchAccounts).Methods("GET")
+	protected.HandleFunc("/transactions/deposit", createDeposit).Methods("POST")
+	protected.HandleFunc("/transactions/withdraw", createWithdrawal).Methods("POST")
+	protected.HandleFunc("/transfers", createTransfer).Methods("POST")
+	protected.HandleFunc("/accounts/{id}/balance", getBalance).Methods("GET")
+	protected.HandleFunc("/accounts/{id}/ledger", getAccountLedger).Methods("GET")
+	protected.HandleFunc("/accounts/{id}/import/ofx", importOFXStatement).Methods("POST")
+	protected.HandleFunc("/accounts/{id}/ofx/fetch", fetchOFXStatement).Methods("POST")
+	protected.HandleFunc("/categories", createCategory).Methods("POST")
+	protected.HandleFunc("/categories", listCategories).Methods("GET")
+	protected.HandleFunc("/categories/{id}", updateCategory).Methods("PATCH")
+	protected.HandleFunc("/categories/{id}", deleteCategory).Methods("DELETE")
+	protected.HandleFunc("/budgets", createBudget).Methods("POST")
+	protected.HandleFunc("/budgets", listBudgets).Methods("GET")
+	protected.HandleFunc("/reports/cashflow", getCashflowReport).Methods("GET")
+
+	return r
 }
 
 // createAccount creates a new bank account
@@ -145,6 +103,11 @@ func createAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	accountType := req.AccountType
+	if accountType == "" {
+		accountType = AccountTypeBank
+	}
+
 	// Check if account number already exists
 	var count int64
 	db.Model(&BankAccount{}).Where("account_number = ?", req.AccountNumber).Count(&count)
@@ -153,13 +116,38 @@ func createAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create account
+	// Create account, owned by the authenticated caller
 	account := BankAccount{
 		AccountHolderName: req.AccountHolderName,
 		AccountNumber:     req.AccountNumber,
+		AccountType:       accountType,
+		OwnerID:           currentUserID(r),
+	}
+
+	var openingEquity *BankAccount
+	if req.StartingBalance != 0 {
+		var err error
+		openingEquity, err = getOrCreateSystemAccount(systemOpeningBalanceAccountNumber, "Opening Balance Equity", AccountTypeEquity)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Opening balance equity account unavailable: "+err.Error())
+			return
+		}
 	}
 
-	if err := db.Create(&account).Error; err != nil {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&account).Error; err != nil {
+			return err
+		}
+		if req.StartingBalance != 0 {
+			_, err := createJournalEntryInTx(tx, "Starting Balance", []Split{
+				{AccountID: account.ID, Amount: req.StartingBalance},
+				{AccountID: openingEquity.ID, Amount: -req.StartingBalance},
+			})
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to create account: "+err.Error())
 		return
 	}
@@ -184,6 +172,9 @@ func searchAccounts(w http.ResponseWriter, r *http.Request) {
 
 	var accounts []BankAccount
 	dbQuery := db.Model(&BankAccount{})
+	if currentRole(r) != RoleAdmin {
+		dbQuery = dbQuery.Where("owner_id = ?", currentUserID(r))
+	}
 
 	if name != "" && number != "" {
 		dbQuery = dbQuery.Where("account_holder_name LIKE ? AND account_number = ?", "%"+name+"%", number)
@@ -214,83 +205,10 @@ func searchAccounts(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// createTransaction handles both deposits and withdrawals
-func createTransaction(w http.ResponseWriter, r *http.Request, transType string) {
-	var req TransactionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
-		return
-	}
-	defer r.Body.Close()
-
-	// Validate input
-	if req.AccountID == 0 || req.Amount <= 0 {
-		respondWithError(w, http.StatusBadRequest, "Valid account ID and positive amount are required")
-		return
-	}
-
-	// Check if account exists
-	var account BankAccount
-	if err := db.First(&account, req.AccountID).Error; err != nil {
-		respondWithError(w, http.StatusNotFound, "Account not found")
-		return
-	}
-
-	// For withdrawals, check if sufficient balance
-	if transType == "debit" {
-		var balance float64
-		balance, err := getAccountBalanceById(req.AccountID)
-		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, "Error checking balance: "+err.Error())
-			return
-		}
-
-		if balance < req.Amount {
-			respondWithError(w, http.StatusBadRequest, "Insufficient funds")
-			return
-		}
-	}
-
-	// Create transaction
-	transaction := Transaction{
-		AccountID: req.AccountID,
-		TransType: transType,
-		Amount:    req.Amount,
-		TransTime: time.Now(),
-	}
-
-	if err := db.Create(&transaction).Error; err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to create transaction: "+err.Error())
-		return
-	}
-
-	// Prepare response message
-	message := "Deposit completed successfully"
-	if transType == "debit" {
-		message = "Withdrawal completed successfully"
-	}
-
-	respondWithJSON(w, http.StatusCreated, Response{
-		Success: true,
-		Message: message,
-		Data:    transaction,
-	})
-}
-
-// createDeposit handles deposit transactions
-func createDeposit(w http.ResponseWriter, r *http.Request) {
-	createTransaction(w, r, "credit")
-}
-
-// createWithdrawal handles withdrawal transactions
-func createWithdrawal(w http.ResponseWriter, r *http.Request) {
-	createTransaction(w, r, "debit")
-}
-
 // getBalance retrieves the current balance for an account
 func getBalance(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
-	id, err := strconv.Atoi(params["id"])
+	id, err := parseAccountID(params["id"])
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid account ID")
 		return
@@ -303,8 +221,13 @@ func getBalance(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !isOwnerOrAdmin(r, account.OwnerID) {
+		respondWithError(w, http.StatusForbidden, "You do not have access to this account")
+		return
+	}
+
 	// Calculate balance
-	balance, err := getAccountBalanceById(uint(id))
+	balance, err := getAccountBalanceById(id)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error calculating balance: "+err.Error())
 		return
@@ -325,27 +248,13 @@ func getBalance(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// getAccountBalanceById calculates the current balance for an account
-func getAccountBalanceById(accountID uint) (float64, error) {
-	var creditSum, debitSum float64
-
-	// Get sum of credits
-	if err := db.Model(&Transaction{}).
-		Where("account_id = ? AND trans_type = ?", accountID, "credit").
-		Select("COALESCE(SUM(amount), 0)").
-		Scan(&creditSum).Error; err != nil {
-		return 0, err
-	}
-
-	// Get sum of debits
-	if err := db.Model(&Transaction{}).
-		Where("account_id = ? AND trans_type = ?", accountID, "debit").
-		Select("COALESCE(SUM(amount), 0)").
-		Scan(&debitSum).Error; err != nil {
+// parseAccountID parses a route-provided account ID into a uint.
+func parseAccountID(raw string) (uint, error) {
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
 		return 0, err
 	}
-
-	return creditSum - debitSum, nil
+	return uint(id), nil
 }
 
 // respondWithError returns an error response
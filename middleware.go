@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// contextKey namespaces values stored on the request context to avoid
+// collisions with other packages.
+type contextKey string
+
+const (
+	contextKeyUserID contextKey = "userID"
+	contextKeyRole   contextKey = "role"
+)
+
+// AuthMiddleware validates the bearer access token on every request and
+// populates the request context with the authenticated user's ID and role.
+func AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		const prefix = "Bearer "
+		if !strings.HasPrefix(header, prefix) {
+			respondWithError(w, http.StatusUnauthorized, "Missing or invalid Authorization header")
+			return
+		}
+
+		claims, err := parseToken(strings.TrimPrefix(header, prefix), tokenTypeAccess)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Invalid or expired token")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), contextKeyUserID, claims.UserID)
+		ctx = context.WithValue(ctx, contextKeyRole, claims.Role)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// currentUserID extracts the authenticated user ID set by AuthMiddleware.
+func currentUserID(r *http.Request) uint {
+	id, _ := r.Context().Value(contextKeyUserID).(uint)
+	return id
+}
+
+// currentRole extracts the authenticated user's role set by AuthMiddleware.
+func currentRole(r *http.Request) Role {
+	role, _ := r.Context().Value(contextKeyRole).(Role)
+	return role
+}
+
+// isOwnerOrAdmin reports whether the authenticated requester either owns
+// ownerID or holds the admin role, which bypasses ownership checks.
+func isOwnerOrAdmin(r *http.Request, ownerID uint) bool {
+	return currentRole(r) == RoleAdmin || currentUserID(r) == ownerID
+}
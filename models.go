@@ -0,0 +1,253 @@
+package main
+
+import "time"
+
+// AccountType classifies a BankAccount for double-entry bookkeeping purposes,
+// modeled on moneygo's account taxonomy.
+type AccountType string
+
+const (
+	AccountTypeBank       AccountType = "Bank"
+	AccountTypeCash       AccountType = "Cash"
+	AccountTypeAsset      AccountType = "Asset"
+	AccountTypeLiability  AccountType = "Liability"
+	AccountTypeIncome     AccountType = "Income"
+	AccountTypeExpense    AccountType = "Expense"
+	AccountTypeEquity     AccountType = "Equity"
+	AccountTypeReceivable AccountType = "Receivable"
+	AccountTypePayable    AccountType = "Payable"
+)
+
+// IsCreditNormal reports whether the account type's balance grows with
+// credits (liabilities, income, equity, payables) rather than debits.
+func (t AccountType) IsCreditNormal() bool {
+	switch t {
+	case AccountTypeLiability, AccountTypeIncome, AccountTypeEquity, AccountTypePayable:
+		return true
+	default:
+		return false
+	}
+}
+
+// BankAccount model
+type BankAccount struct {
+	ID                uint        `json:"id" gorm:"primaryKey"`
+	AccountHolderName string      `json:"account_holder_name" gorm:"size:255;not null"`
+	AccountNumber     string      `json:"account_number" gorm:"size:50;not null;uniqueIndex"`
+	AccountType       AccountType `json:"account_type" gorm:"size:20;not null;default:Bank"`
+	OwnerID           uint        `json:"owner_id" gorm:"index"`
+
+	// OFX statement import/fetch credentials, as in moneygo.
+	OFXURL     string `json:"ofx_url,omitempty" gorm:"size:255"`
+	OFXOrg     string `json:"ofx_org,omitempty" gorm:"size:255"`
+	OFXFID     string `json:"ofx_fid,omitempty" gorm:"size:255"`
+	OFXUser    string `json:"ofx_user,omitempty" gorm:"size:255"`
+	OFXBankID  string `json:"ofx_bank_id,omitempty" gorm:"size:255"`
+	OFXAcctID  string `json:"ofx_acct_id,omitempty" gorm:"size:255"`
+
+	Splits []Split `json:"-" gorm:"foreignKey:AccountID"`
+}
+
+// Role distinguishes regular users from admins, who bypass account ownership
+// checks.
+type Role string
+
+const (
+	RoleUser  Role = "user"
+	RoleAdmin Role = "admin"
+)
+
+// User model
+type User struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Email        string    `json:"email" gorm:"size:255;not null;uniqueIndex"`
+	PasswordHash string    `json:"-" gorm:"size:255;not null"`
+	Role         Role      `json:"role" gorm:"size:20;not null;default:user"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RegisterUserRequest structure for user registration
+type RegisterUserRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// LoginRequest structure for user login
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// RefreshRequest structure for POST /tokens/refresh
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenResponse carries the access/refresh token pair returned on login and
+// refresh.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// JournalEntry is the header for a balanced set of Splits. Splits belonging
+// to the same JournalEntry must sum to zero.
+type JournalEntry struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Memo      string    `json:"memo" gorm:"size:255"`
+	EntryTime time.Time `json:"entry_time" gorm:"not null"`
+	Splits    []Split   `json:"splits,omitempty" gorm:"foreignKey:JournalEntryID"`
+}
+
+// Split is a single debit/credit line of a JournalEntry against one account.
+// Amount is signed: positive increases the account's raw balance, negative
+// decreases it. The account-visible balance sign depends on AccountType.
+type Split struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	JournalEntryID uint      `json:"journal_entry_id" gorm:"not null;index"`
+	AccountID      uint      `json:"account_id" gorm:"not null;index;uniqueIndex:idx_split_account_fitid"`
+	Amount         float64   `json:"amount" gorm:"type:decimal(15,2);not null"`
+	Memo           string    `json:"memo" gorm:"size:255"`
+	// FITID is the OFX financial institution transaction ID for splits
+	// created by statement import; nil for splits created any other way.
+	// The (account_id, fitid) unique index makes re-importing a statement
+	// idempotent.
+	FITID     *string   `json:"fitid,omitempty" gorm:"size:255;uniqueIndex:idx_split_account_fitid"`
+	// CategoryID optionally classifies this split for budgeting/reporting.
+	CategoryID *uint     `json:"category_id,omitempty" gorm:"index"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CategoryType classifies a Category for budgeting and cashflow reporting.
+type CategoryType string
+
+const (
+	CategoryTypeIncome   CategoryType = "income"
+	CategoryTypeExpense  CategoryType = "expense"
+	CategoryTypeTransfer CategoryType = "transfer"
+)
+
+// Category groups splits for budgeting and cashflow reporting, optionally
+// nested under a parent category.
+type Category struct {
+	ID       uint         `json:"id" gorm:"primaryKey"`
+	Name     string       `json:"name" gorm:"size:255;not null"`
+	ParentID *uint        `json:"parent_id,omitempty" gorm:"index"`
+	Type     CategoryType `json:"type" gorm:"size:20;not null"`
+}
+
+// Budget allocates an amount to a category for a given month, following the
+// luzifer/accounting "budget envelope" pattern.
+type Budget struct {
+	ID              uint    `json:"id" gorm:"primaryKey"`
+	Month           string  `json:"month" gorm:"size:7;not null;uniqueIndex:idx_budget_month_category"`
+	CategoryID      uint    `json:"category_id" gorm:"not null;uniqueIndex:idx_budget_month_category"`
+	AllocatedAmount float64 `json:"allocated_amount" gorm:"type:decimal(15,2);not null"`
+}
+
+// CategoryRequest structure for category create/update
+type CategoryRequest struct {
+	Name     string       `json:"name"`
+	ParentID *uint        `json:"parent_id,omitempty"`
+	Type     CategoryType `json:"type"`
+}
+
+// BudgetRequest structure for budget creation
+type BudgetRequest struct {
+	Month           string  `json:"month"`
+	CategoryID      uint    `json:"category_id"`
+	AllocatedAmount float64 `json:"allocated_amount"`
+}
+
+// CashflowReportEntry is one grouped row of GET /reports/cashflow.
+type CashflowReportEntry struct {
+	Category string  `json:"category"`
+	Credits  float64 `json:"credits"`
+	Debits   float64 `json:"debits"`
+	Net      float64 `json:"net"`
+}
+
+// CreateAccountRequest structure for account creation
+type CreateAccountRequest struct {
+	AccountHolderName string      `json:"account_holder_name"`
+	AccountNumber     string      `json:"account_number"`
+	AccountType       AccountType `json:"account_type"`
+	// StartingBalance, when nonzero, seeds the new account with a Starting
+	// Balance journal entry so historical balances are representable from
+	// day one.
+	StartingBalance float64 `json:"starting_balance,omitempty"`
+}
+
+// TransactionRequest structure for deposit/withdraw requests
+type TransactionRequest struct {
+	AccountID uint    `json:"account_id"`
+	Amount    float64 `json:"amount"`
+	// CategoryID optionally classifies the resulting split for budgeting and
+	// cashflow reporting.
+	CategoryID *uint `json:"category_id,omitempty"`
+}
+
+// TransferRequest structure for inter-account transfers
+type TransferRequest struct {
+	FromAccountID uint    `json:"from_account_id"`
+	ToAccountID   uint    `json:"to_account_id"`
+	Amount        float64 `json:"amount"`
+	Memo          string  `json:"memo"`
+	// CategoryID optionally classifies the resulting splits for budgeting
+	// and cashflow reporting.
+	CategoryID *uint `json:"category_id,omitempty"`
+}
+
+// Response structures
+type Response struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// BalanceResponse structure
+type BalanceResponse struct {
+	AccountID     uint    `json:"account_id"`
+	AccountNumber string  `json:"account_number"`
+	AccountHolder string  `json:"account_holder"`
+	Balance       float64 `json:"balance"`
+}
+
+// LedgerEntry is a single Split enriched with a running balance, as returned
+// by GET /accounts/{id}/ledger.
+type LedgerEntry struct {
+	SplitID        uint      `json:"split_id"`
+	JournalEntryID uint      `json:"journal_entry_id"`
+	Amount         float64   `json:"amount"`
+	Memo           string    `json:"memo"`
+	CreatedAt      time.Time `json:"created_at"`
+	RunningBalance float64   `json:"running_balance"`
+}
+
+// OFXImportResult summarizes the outcome of an OFX statement import.
+type OFXImportResult struct {
+	Imported          int      `json:"imported"`
+	SkippedDuplicates int      `json:"skipped_duplicates"`
+	Errors            []string `json:"errors"`
+}
+
+// OFXFetchRequest carries the password needed to sign on to the financial
+// institution when pulling a statement with the account's stored OFX
+// credentials.
+type OFXFetchRequest struct {
+	Password string `json:"password"`
+}
+
+// IdempotencyRecord remembers the outcome of a previous request made with
+// the same Idempotency-Key header against the same account, so retries
+// replay the original response instead of re-executing the operation.
+type IdempotencyRecord struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	AccountID    uint      `json:"account_id" gorm:"not null;uniqueIndex:idx_idempotency_account_key"`
+	Key          string    `json:"key" gorm:"size:255;not null;uniqueIndex:idx_idempotency_account_key"`
+	RequestHash  string    `json:"request_hash" gorm:"size:64;not null"`
+	ResponseBody string    `json:"response_body" gorm:"type:text;not null"`
+	ResponseCode int       `json:"response_code" gorm:"not null"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// systemOFXImportAccountNumber is the counterparty account credited/debited
+// against imported OFX transactions, mirroring the system Cash account used
+// for plain deposits/withdrawals.
+const systemOFXImportAccountNumber = "SYSTEM-OFX-IMPORT"
+
+// ofxStmtTrn is a single <STMTTRN> record, covering both OFX 2.x (XML) and
+// normalized OFX 1.x (SGML) documents.
+type ofxStmtTrn struct {
+	TrnType  string `xml:"TRNTYPE"`
+	DtPosted string `xml:"DTPOSTED"`
+	TrnAmt   string `xml:"TRNAMT"`
+	FITID    string `xml:"FITID"`
+	Name     string `xml:"NAME"`
+	Memo     string `xml:"MEMO"`
+}
+
+// ofxTrnTypeSign maps an OFX TRNTYPE to the sign its TRNAMT must carry
+// against the imported account: +1 for types that always add money
+// (CREDIT/INT/DIV), -1 for types that always remove it (DEBIT/CHECK/FEE).
+// XFER is intentionally absent: a transfer can move money either way, so its
+// direction comes from TRNAMT's own sign rather than the type.
+var ofxTrnTypeSign = map[string]float64{
+	"CREDIT": 1,
+	"INT":    1,
+	"DIV":    1,
+	"DEBIT":  -1,
+	"CHECK":  -1,
+	"FEE":    -1,
+}
+
+// sgmlUnclosedTag matches a leaf SGML tag with an inline value and no
+// closing tag, like "<FITID>1234", as emitted by OFX 1.x servers. The value
+// group requires at least one character so a bare container-opening line
+// like "<STMTTRN>" isn't mistaken for an unclosed leaf and self-closed
+// before the fields nested under it are read; containers are always closed
+// explicitly later in the document and so pass through untouched.
+var sgmlUnclosedTag = regexp.MustCompile(`^<([A-Za-z0-9.]+)>([^<]+)$`)
+
+// normalizeOFXSGML closes unclosed leaf tags in an OFX 1.x SGML document so
+// it can be fed to an XML decoder. Container tags (already closed, or whose
+// value contains further markup) are left untouched.
+func normalizeOFXSGML(data []byte) []byte {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var out bytes.Buffer
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+		if m := sgmlUnclosedTag.FindStringSubmatch(trimmed); m != nil {
+			tag, value := m[1], strings.TrimSpace(m[2])
+			out.WriteString(fmt.Sprintf("<%s>%s</%s>\n", tag, escapeXMLText(value), tag))
+			continue
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	return out.Bytes()
+}
+
+// escapeXMLText escapes characters that would otherwise break the
+// normalized XML produced by normalizeOFXSGML.
+func escapeXMLText(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// isOFXSGML reports whether data looks like an OFX 1.x SGML document rather
+// than OFX 2.x XML (which starts with an XML/OFX processing instruction).
+func isOFXSGML(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return !bytes.HasPrefix(trimmed, []byte("<?xml")) && !bytes.HasPrefix(trimmed, []byte("<?OFX"))
+}
+
+// splitOFXHeader strips the plain-text OFX header block (the lines before
+// the first "<" of the body) that precedes both SGML and XML OFX documents.
+func splitOFXHeader(data []byte) []byte {
+	if idx := bytes.IndexByte(data, '<'); idx >= 0 {
+		return data[idx:]
+	}
+	return data
+}
+
+// parseOFXTransactions extracts every <STMTTRN> record from an OFX 1.x or
+// 2.x statement document.
+func parseOFXTransactions(data []byte) ([]ofxStmtTrn, error) {
+	body := splitOFXHeader(data)
+	if isOFXSGML(data) {
+		body = normalizeOFXSGML(body)
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.Strict = false
+
+	var transactions []ofxStmtTrn
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return transactions, fmt.Errorf("malformed OFX document: %v", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "STMTTRN" {
+			continue
+		}
+		var trn ofxStmtTrn
+		if err := decoder.DecodeElement(&trn, &start); err != nil {
+			return transactions, fmt.Errorf("malformed STMTTRN record: %v", err)
+		}
+		transactions = append(transactions, trn)
+	}
+	return transactions, nil
+}
+
+// importOFXTransactions inserts the given statement transactions against
+// account as balanced journal entries, skipping FITIDs already imported.
+func importOFXTransactions(account *BankAccount, counterparty *BankAccount, transactions []ofxStmtTrn) OFXImportResult {
+	var result OFXImportResult
+
+	for _, trn := range transactions {
+		if trn.FITID == "" {
+			result.Errors = append(result.Errors, "skipped STMTTRN with missing FITID")
+			continue
+		}
+
+		var existing Split
+		err := db.Where("account_id = ? AND fitid = ?", account.ID, trn.FITID).First(&existing).Error
+		if err == nil {
+			result.SkippedDuplicates++
+			continue
+		}
+
+		amount, err := strconv.ParseFloat(strings.TrimSpace(trn.TrnAmt), 64)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("FITID %s: invalid TRNAMT %q", trn.FITID, trn.TrnAmt))
+			continue
+		}
+
+		// TRNTYPE, when it unambiguously implies a direction, is
+		// authoritative over TRNAMT's sign: some FIs export unsigned
+		// amounts for FEE/INT/DIV and expect the reader to infer the sign
+		// from TRNTYPE.
+		trnType := strings.ToUpper(strings.TrimSpace(trn.TrnType))
+		if sign, ok := ofxTrnTypeSign[trnType]; ok {
+			amount = sign * math.Abs(amount)
+		}
+
+		memo := trn.Name
+		if trn.Memo != "" {
+			memo = trn.Memo
+		}
+		fitid := trn.FITID
+
+		_, err = createJournalEntry(memo, []Split{
+			{AccountID: account.ID, Amount: amount, Memo: memo, FITID: &fitid},
+			{AccountID: counterparty.ID, Amount: -amount, Memo: memo},
+		})
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("FITID %s: %v", trn.FITID, err))
+			continue
+		}
+		result.Imported++
+	}
+
+	return result
+}
+
+// importOFXStatement handles POST /accounts/{id}/import/ofx, accepting a
+// multipart-uploaded OFX 1.x or 2.x statement file.
+func importOFXStatement(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := parseAccountID(params["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid account ID")
+		return
+	}
+
+	var account BankAccount
+	if err := db.First(&account, id).Error; err != nil {
+		respondWithError(w, http.StatusNotFound, "Account not found")
+		return
+	}
+	if !isOwnerOrAdmin(r, account.OwnerID) {
+		respondWithError(w, http.StatusForbidden, "You do not have access to this account")
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "A multipart \"file\" field with the OFX statement is required")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to read uploaded file: "+err.Error())
+		return
+	}
+
+	transactions, err := parseOFXTransactions(data)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to parse OFX statement: "+err.Error())
+		return
+	}
+
+	counterparty, err := getOrCreateSystemAccount(systemOFXImportAccountNumber, "System OFX Import", AccountTypeCash)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "System OFX import account unavailable: "+err.Error())
+		return
+	}
+
+	result := importOFXTransactions(&account, counterparty, transactions)
+
+	respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: fmt.Sprintf("Imported %d transaction(s), skipped %d duplicate(s)", result.Imported, result.SkippedDuplicates),
+		Data:    result,
+	})
+}
+
+// fetchOFXStatement handles POST /accounts/{id}/ofx/fetch, pulling a
+// statement over HTTPS from the account's stored OFX endpoint using the OFX
+// SIGNONMSGSRQV1/STMTTRNRQ request envelope and importing the result.
+func fetchOFXStatement(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id, err := parseAccountID(params["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid account ID")
+		return
+	}
+
+	var account BankAccount
+	if err := db.First(&account, id).Error; err != nil {
+		respondWithError(w, http.StatusNotFound, "Account not found")
+		return
+	}
+	if !isOwnerOrAdmin(r, account.OwnerID) {
+		respondWithError(w, http.StatusForbidden, "You do not have access to this account")
+		return
+	}
+	if account.OFXURL == "" {
+		respondWithError(w, http.StatusBadRequest, "Account has no OFX endpoint configured")
+		return
+	}
+
+	var req OFXFetchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	envelope := buildOFXStatementRequest(&account, req.Password)
+	httpReq, err := http.NewRequest(http.MethodPost, account.OFXURL, strings.NewReader(envelope))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to build OFX request: "+err.Error())
+		return
+	}
+	httpReq.Header.Set("Content-Type", "application/x-ofx")
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, "Failed to reach OFX server: "+err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, "Failed to read OFX response: "+err.Error())
+		return
+	}
+
+	transactions, err := parseOFXTransactions(data)
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, "Failed to parse OFX response: "+err.Error())
+		return
+	}
+
+	counterparty, err := getOrCreateSystemAccount(systemOFXImportAccountNumber, "System OFX Import", AccountTypeCash)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "System OFX import account unavailable: "+err.Error())
+		return
+	}
+
+	result := importOFXTransactions(&account, counterparty, transactions)
+
+	respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: fmt.Sprintf("Fetched and imported %d transaction(s), skipped %d duplicate(s)", result.Imported, result.SkippedDuplicates),
+		Data:    result,
+	})
+}
+
+// buildOFXStatementRequest renders the minimal OFX 2.x
+// SIGNONMSGSRQV1/STMTTRNRQ envelope needed to request a bank statement using
+// the account's stored OFX credentials.
+func buildOFXStatementRequest(account *BankAccount, password string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+<SIGNONMSGSRQV1>
+<SONRQ>
+<DTCLIENT>%s</DTCLIENT>
+<USERID>%s</USERID>
+<USERPASS>%s</USERPASS>
+<LANGUAGE>ENG</LANGUAGE>
+<FI>
+<ORG>%s</ORG>
+<FID>%s</FID>
+</FI>
+<APPID>QWIN</APPID>
+<APPVER>2700</APPVER>
+</SONRQ>
+</SIGNONMSGSRQV1>
+<BANKMSGSRQV1>
+<STMTTRNRQ>
+<TRNUID>1</TRNUID>
+<STMTRQ>
+<BANKACCTFROM>
+<BANKID>%s</BANKID>
+<ACCTID>%s</ACCTID>
+<ACCTTYPE>CHECKING</ACCTTYPE>
+</BANKACCTFROM>
+<INCTRAN>
+<INCLUDE>Y</INCLUDE>
+</INCTRAN>
+</STMTRQ>
+</STMTTRNRQ>
+</BANKMSGSRQV1>
+</OFX>
+`, time.Now().Format("20060102150405"), account.OFXUser, password, account.OFXOrg, account.OFXFID, account.OFXBankID, account.OFXAcctID)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestParseOFXTransactionsSGML covers the OFX 1.x SGML path: unclosed leaf
+// tags normalized to well-formed XML, with the STMTTRN container's closing
+// tag (and its siblings) preserved so the transaction's fields end up nested
+// under it rather than self-closed away.
+func TestParseOFXTransactionsSGML(t *testing.T) {
+	const sgml = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+SECURITY:NONE
+ENCODING:USASCII
+
+<OFX>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<STMTRS>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20260105120000
+<TRNAMT>-42.50
+<FITID>SGML-001
+<NAME>Grocery Store
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`
+
+	transactions, err := parseOFXTransactions([]byte(sgml))
+	if err != nil {
+		t.Fatalf("parseOFXTransactions: %v", err)
+	}
+	if len(transactions) != 1 {
+		t.Fatalf("got %d transactions, want 1", len(transactions))
+	}
+
+	trn := transactions[0]
+	if trn.TrnType != "DEBIT" || trn.TrnAmt != "-42.50" || trn.FITID != "SGML-001" || trn.Name != "Grocery Store" {
+		t.Fatalf("unexpected transaction: %+v", trn)
+	}
+}
+
+// TestParseOFXTransactionsXML covers the OFX 2.x XML path.
+func TestParseOFXTransactionsXML(t *testing.T) {
+	const doc = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<STMTRS>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>CREDIT</TRNTYPE>
+<DTPOSTED>20260106120000</DTPOSTED>
+<TRNAMT>100.00</TRNAMT>
+<FITID>XML-001</FITID>
+<NAME>Paycheck</NAME>
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`
+
+	transactions, err := parseOFXTransactions([]byte(doc))
+	if err != nil {
+		t.Fatalf("parseOFXTransactions: %v", err)
+	}
+	if len(transactions) != 1 {
+		t.Fatalf("got %d transactions, want 1", len(transactions))
+	}
+
+	trn := transactions[0]
+	if trn.TrnType != "CREDIT" || trn.TrnAmt != "100.00" || trn.FITID != "XML-001" || trn.Name != "Paycheck" {
+		t.Fatalf("unexpected transaction: %+v", trn)
+	}
+}
+
+// ofxTestAccounts creates a fresh Bank account (with no owner) and the system OFX
+// import counterparty against the test DB set up by the caller.
+func ofxTestAccounts(t *testing.T, accountNumber string) (*BankAccount, *BankAccount) {
+	t.Helper()
+
+	account := BankAccount{
+		AccountHolderName: "Holder",
+		AccountNumber:     accountNumber,
+		AccountType:       AccountTypeBank,
+	}
+	if err := db.Create(&account).Error; err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+
+	counterparty, err := getOrCreateSystemAccount(systemOFXImportAccountNumber, "System OFX Import", AccountTypeCash)
+	if err != nil {
+		t.Fatalf("getOrCreateSystemAccount: %v", err)
+	}
+
+	return &account, counterparty
+}
+
+// TestImportOFXTransactionsSignsUnsignedAmountsByTrnType covers the case the
+// chunk0-3 fix targets: some FIs export FEE/INT/DIV records with an unsigned
+// TRNAMT and expect the reader to infer direction from TRNTYPE.
+func TestImportOFXTransactionsSignsUnsignedAmountsByTrnType(t *testing.T) {
+	setupTestDB(t, driverSQLite)
+	account, counterparty := ofxTestAccounts(t, "OFX-SIGN-TEST")
+
+	cases := []struct {
+		name       string
+		trnType    string
+		trnAmt     string
+		wantAmount float64
+	}{
+		{"unsigned fee posts as a debit", "FEE", "5.00", -5.00},
+		{"unsigned interest posts as a credit", "INT", "7.25", 7.25},
+		{"unsigned dividend posts as a credit", "DIV", "12.00", 12.00},
+	}
+
+	for i, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fitid := fmt.Sprintf("SIGN-%d", i)
+			result := importOFXTransactions(account, counterparty, []ofxStmtTrn{
+				{TrnType: tc.trnType, TrnAmt: tc.trnAmt, FITID: fitid, Name: tc.name},
+			})
+			if result.Imported != 1 || len(result.Errors) != 0 {
+				t.Fatalf("import result: %+v", result)
+			}
+
+			var split Split
+			if err := db.Where("account_id = ? AND fitid = ?", account.ID, fitid).First(&split).Error; err != nil {
+				t.Fatalf("fetch split: %v", err)
+			}
+			if split.Amount != tc.wantAmount {
+				t.Fatalf("split amount: got %v, want %v", split.Amount, tc.wantAmount)
+			}
+		})
+	}
+}
+
+// TestImportOFXTransactionsSkipsDuplicateFITID covers re-importing the same
+// statement: the second pass must skip the already-imported FITID instead of
+// double-posting it.
+func TestImportOFXTransactionsSkipsDuplicateFITID(t *testing.T) {
+	setupTestDB(t, driverSQLite)
+	account, counterparty := ofxTestAccounts(t, "OFX-DEDUP-TEST")
+
+	transactions := []ofxStmtTrn{
+		{TrnType: "DEBIT", TrnAmt: "-20.00", FITID: "DEDUP-1", Name: "Coffee Shop"},
+	}
+
+	first := importOFXTransactions(account, counterparty, transactions)
+	if first.Imported != 1 || first.SkippedDuplicates != 0 {
+		t.Fatalf("first import: %+v", first)
+	}
+
+	second := importOFXTransactions(account, counterparty, transactions)
+	if second.Imported != 0 || second.SkippedDuplicates != 1 {
+		t.Fatalf("second import: %+v", second)
+	}
+
+	var count int64
+	db.Model(&Split{}).Where("account_id = ? AND fitid = ?", account.ID, "DEDUP-1").Count(&count)
+	if count != 1 {
+		t.Fatalf("expected exactly one split for FITID DEDUP-1, got %d", count)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/glebarez/sqlite"
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const (
+	driverMySQL    = "mysql"
+	driverPostgres = "postgres"
+	driverSQLite   = "sqlite"
+)
+
+// openDialector builds the GORM dialector for the driver selected by
+// cfg.DBDriver, mirroring moneygo's and luzifer/accounting's pluggable
+// storage backends.
+func openDialector() (gorm.Dialector, error) {
+	switch cfg.DBDriver {
+	case driverMySQL:
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local&allowNativePasswords=true",
+			cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
+		return mysql.Open(dsn), nil
+	case driverPostgres:
+		dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+			cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
+		return postgres.Open(dsn), nil
+	case driverSQLite:
+		// busy_timeout makes a writer that loses the race for the file lock
+		// block and retry for up to 5s instead of failing immediately with
+		// SQLITE_BUSY; limitSQLiteConnections caps the pool to one
+		// connection so GORM can't itself open a second writer that would
+		// contend against the first.
+		return sqlite.Open(cfg.SQLitePath + "?_pragma=busy_timeout(5000)"), nil
+	default:
+		return nil, fmt.Errorf("unsupported DB_DRIVER %q (want mysql, postgres, or sqlite)", cfg.DBDriver)
+	}
+}
+
+// limitSQLiteConnections caps gdb's pool to a single open connection. GORM
+// otherwise happily opens several connections to the same SQLite file, and
+// since db.Transaction issues a plain BEGIN on whichever connection it gets,
+// two of those connections can hold the file's write lock from two
+// concurrent transactions at once, which SQLite resolves by failing one with
+// SQLITE_BUSY rather than blocking it. Routing every connection through a
+// single pooled connection forces concurrent transactions to actually queue.
+func limitSQLiteConnections(gdb *gorm.DB) error {
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(1)
+	return nil
+}
+
+// createDatabaseIfNotExists ensures the target database exists before GORM
+// connects to it. SQLite needs no bootstrap: the driver creates the file on
+// first connection.
+func createDatabaseIfNotExists() error {
+	switch cfg.DBDriver {
+	case driverSQLite:
+		return nil
+	case driverPostgres:
+		return createPostgresDatabaseIfNotExists()
+	case driverMySQL:
+		return createMySQLDatabaseIfNotExists()
+	default:
+		return fmt.Errorf("unsupported DB_DRIVER %q (want mysql, postgres, or sqlite)", cfg.DBDriver)
+	}
+}
+
+// createMySQLDatabaseIfNotExists connects without selecting a database and
+// issues a CREATE DATABASE IF NOT EXISTS.
+func createMySQLDatabaseIfNotExists() error {
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/", cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort)
+	sqlDB, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return fmt.Errorf("error connecting to MySQL: %v", err)
+	}
+	defer sqlDB.Close()
+
+	if _, err := sqlDB.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", cfg.DBName)); err != nil {
+		return fmt.Errorf("error creating database: %v", err)
+	}
+	return nil
+}
+
+// createPostgresDatabaseIfNotExists connects to the maintenance "postgres"
+// database and creates the target database if it isn't already present;
+// unlike MySQL, Postgres has no CREATE DATABASE IF NOT EXISTS.
+func createPostgresDatabaseIfNotExists() error {
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=postgres sslmode=disable",
+		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword)
+	sqlDB, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return fmt.Errorf("error connecting to Postgres: %v", err)
+	}
+	defer sqlDB.Close()
+
+	var exists bool
+	if err := sqlDB.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)", cfg.DBName).Scan(&exists); err != nil {
+		return fmt.Errorf("error checking for database: %v", err)
+	}
+	if exists {
+		return nil
+	}
+
+	if _, err := sqlDB.Exec(fmt.Sprintf("CREATE DATABASE %s", cfg.DBName)); err != nil {
+		return fmt.Errorf("error creating database: %v", err)
+	}
+	return nil
+}
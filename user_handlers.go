@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// registerUser handles POST /users, creating a new user with a bcrypt-hashed
+// password.
+func registerUser(w http.ResponseWriter, r *http.Request) {
+	var req RegisterUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Email == "" || req.Password == "" {
+		respondWithError(w, http.StatusBadRequest, "Email and password are required")
+		return
+	}
+
+	var count int64
+	db.Model(&User{}).Where("email = ?", req.Email).Count(&count)
+	if count > 0 {
+		respondWithError(w, http.StatusConflict, "Email already registered")
+		return
+	}
+
+	hash, err := hashPassword(req.Password)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to hash password: "+err.Error())
+		return
+	}
+
+	user := User{
+		Email:        req.Email,
+		PasswordHash: hash,
+		Role:         RoleUser,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create user: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, Response{
+		Success: true,
+		Message: "User registered successfully",
+		Data:    user,
+	})
+}
+
+// loginUser handles POST /users/login, returning a signed access/refresh
+// token pair on success.
+func loginUser(w http.ResponseWriter, r *http.Request) {
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	var user User
+	if err := db.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid email or password")
+		return
+	}
+
+	if !checkPassword(user.PasswordHash, req.Password) {
+		respondWithError(w, http.StatusUnauthorized, "Invalid email or password")
+		return
+	}
+
+	tokens, err := issueTokenPair(&user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to issue tokens: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Login successful",
+		Data:    tokens,
+	})
+}
+
+// refreshAccessToken handles POST /tokens/refresh, exchanging a valid refresh
+// token for a new access/refresh token pair.
+func refreshAccessToken(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	claims, err := parseToken(req.RefreshToken, tokenTypeRefresh)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired refresh token")
+		return
+	}
+
+	var user User
+	if err := db.First(&user, claims.UserID).Error; err != nil {
+		respondWithError(w, http.StatusUnauthorized, "User no longer exists")
+		return
+	}
+
+	tokens, err := issueTokenPair(&user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to issue tokens: "+err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, Response{
+		Success: true,
+		Message: "Token refreshed successfully",
+		Data:    tokens,
+	})
+}